@@ -0,0 +1,168 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ast
+
+// Node is implemented by every type in this package that can appear in a
+// Definition's tree: Definitions themselves, as well as the Field,
+// Annotation, and EnumItem nodes nested inside them.
+//
+// Type and ConstantValue are also Nodes; their concrete implementations
+// live alongside their declarations.
+type Node interface {
+	node()
+}
+
+func (c *Constant) node()         {}
+func (t *Typedef) node()          {}
+func (e *Enum) node()             {}
+func (i *EnumItem) node()         {}
+func (s *Struct) node()           {}
+func (sv *Service) node()         {}
+func (f *Function) node()         {}
+func (fl *Field) node()           {}
+func (r *ServiceReference) node() {}
+
+// Walker visits nodes of a Definition tree. Walk calls Visit for every
+// Node it descends into; a nil return value from Visit stops the
+// recursion into that node's children, mirroring ast.Visitor in the
+// standard library's go/ast package.
+type Walker interface {
+	Visit(Node) Walker
+}
+
+// Walk traverses a Definition tree in depth-first order, calling
+// w.Visit(n) for the Definition itself and for every Field, Type,
+// ConstantValue, Annotation, EnumItem, and ServiceReference nested
+// inside it.
+//
+// Walk(nil, d) is a no-op. If w.Visit(d) returns a non-nil walker w2,
+// Walk recurses into d's children using w2; if it returns nil, Walk does
+// not descend into d's children.
+func Walk(w Walker, d Definition) {
+	if w == nil || d == nil {
+		return
+	}
+	walk(w, d.(Node))
+}
+
+// Inspect traverses a Definition tree in depth-first order, calling f
+// for the Definition itself and for every nested Node. If f returns
+// false, Inspect does not descend into the current node's children.
+//
+// Inspect(d, f) is equivalent to Walk(inspector(f), d).
+func Inspect(d Definition, f func(Node) bool) {
+	Walk(inspector(f), d)
+}
+
+// inspector adapts a func(Node) bool into a Walker, so that Inspect can
+// be implemented directly in terms of Walk.
+type inspector func(Node) bool
+
+func (f inspector) Visit(n Node) Walker {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// walk calls w.Visit(n) and, if that returns a non-nil walker w2,
+// dispatches on the concrete type of n and recurses into its children
+// using w2.
+func walk(w Walker, n Node) {
+	w2 := w.Visit(n)
+	if w2 == nil {
+		return
+	}
+
+	switch n := n.(type) {
+	case *Constant:
+		walkType(w2, n.Type)
+		walkConstantValue(w2, n.Value)
+	case *Typedef:
+		walkType(w2, n.Type)
+		walkAnnotations(w2, n.Annotations)
+	case *Enum:
+		for _, item := range n.Items {
+			walk(w2, item)
+		}
+		walkAnnotations(w2, n.Annotations)
+	case *EnumItem:
+		walkAnnotations(w2, n.Annotations)
+	case *Struct:
+		for _, field := range n.Fields {
+			walk(w2, field)
+		}
+		walkAnnotations(w2, n.Annotations)
+	case *Service:
+		for _, fn := range n.Functions {
+			walk(w2, fn)
+		}
+		if n.Parent != nil {
+			walk(w2, n.Parent)
+		}
+		walkAnnotations(w2, n.Annotations)
+	case *Function:
+		for _, p := range n.Parameters {
+			walk(w2, p)
+		}
+		for _, e := range n.Exceptions {
+			walk(w2, e)
+		}
+		walkType(w2, n.ReturnType)
+		walkAnnotations(w2, n.Annotations)
+	case *Field:
+		walkType(w2, n.Type)
+		walkConstantValue(w2, n.Default)
+		walkAnnotations(w2, n.Annotations)
+	case *ServiceReference:
+		// No children.
+	}
+}
+
+// walkType visits t with w, if t is non-nil. Type's concrete
+// implementations (BaseType, MapType, ListType, ...) live alongside
+// their declarations outside this file, but all of them implement Node,
+// so plugins walking the tree for type information (to generate
+// validators, OpenAPI specs, or linters) see them the same way they see
+// any other nested node.
+func walkType(w Walker, t Type) {
+	if t == nil {
+		return
+	}
+	walk(w, t.(Node))
+}
+
+// walkConstantValue visits v with w, if v is non-nil. See walkType for
+// why ConstantValue, like Type, can be passed straight to walk.
+func walkConstantValue(w Walker, v ConstantValue) {
+	if v == nil {
+		return
+	}
+	walk(w, v.(Node))
+}
+
+// walkAnnotations visits each annotation in anns with w. Annotation
+// implements Node in its own file alongside its declaration.
+func walkAnnotations(w Walker, anns []*Annotation) {
+	for _, a := range anns {
+		walk(w, a)
+	}
+}
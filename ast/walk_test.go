@@ -0,0 +1,185 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectStruct(t *testing.T) {
+	s := &Struct{
+		Name: "User",
+		Type: StructType,
+		Fields: []*Field{
+			{ID: 1, Name: "name"},
+			{ID: 2, Name: "status"},
+		},
+	}
+
+	var visited []string
+	Inspect(s, func(n Node) bool {
+		switch n := n.(type) {
+		case *Struct:
+			visited = append(visited, "struct:"+n.Name)
+		case *Field:
+			visited = append(visited, "field:"+n.Name)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"struct:User", "field:name", "field:status"}, visited)
+}
+
+func TestInspectServiceWithParent(t *testing.T) {
+	svc := &Service{
+		Name: "Derived",
+		Functions: []*Function{
+			{Name: "ping", OneWay: true},
+		},
+		Parent: &ServiceReference{Name: "Base"},
+	}
+
+	var visited []string
+	Inspect(svc, func(n Node) bool {
+		switch n := n.(type) {
+		case *Service:
+			visited = append(visited, "service:"+n.Name)
+		case *Function:
+			visited = append(visited, "function:"+n.Name)
+		case *ServiceReference:
+			visited = append(visited, "parent:"+n.Name)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"service:Derived", "function:ping", "parent:Base"}, visited)
+}
+
+func TestInspectStopsDescent(t *testing.T) {
+	s := &Struct{
+		Name:   "User",
+		Fields: []*Field{{ID: 1, Name: "name"}},
+	}
+
+	var visited []string
+	Inspect(s, func(n Node) bool {
+		if _, ok := n.(*Struct); ok {
+			visited = append(visited, "struct")
+			return false // do not descend into Fields
+		}
+		visited = append(visited, "unexpected")
+		return true
+	})
+
+	assert.Equal(t, []string{"struct"}, visited)
+}
+
+func TestWalkNilIsNoop(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Walk(nil, &Struct{Name: "User"})
+		Walk(inspector(func(Node) bool { return true }), nil)
+	})
+}
+
+type countingWalker struct {
+	count *int
+}
+
+func (w countingWalker) Visit(n Node) Walker {
+	*w.count++
+	return w
+}
+
+// testBaseType is a minimal stand-in for the real Type implementations
+// (BaseType, MapType, ListType, ...), which live outside this chunk of
+// the tree; it exists only to exercise walkType/walkConstantValue.
+type testBaseType struct{ name string }
+
+func (t *testBaseType) node() {}
+
+// testLiteralValue is a minimal stand-in for a real ConstantValue
+// implementation, for the same reason as testBaseType above.
+type testLiteralValue struct{ value string }
+
+func (v *testLiteralValue) node() {}
+
+func TestInspectDescendsIntoTypeAndConstantValue(t *testing.T) {
+	c := &Constant{
+		Name:  "foo",
+		Type:  &testBaseType{name: "i32"},
+		Value: &testLiteralValue{value: "42"},
+	}
+
+	var visited []string
+	Inspect(c, func(n Node) bool {
+		switch n := n.(type) {
+		case *Constant:
+			visited = append(visited, "constant:"+n.Name)
+		case *testBaseType:
+			visited = append(visited, "type:"+n.name)
+		case *testLiteralValue:
+			visited = append(visited, "value:"+n.value)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"constant:foo", "type:i32", "value:42"}, visited)
+}
+
+func TestInspectDescendsIntoFieldTypeAndDefault(t *testing.T) {
+	s := &Struct{
+		Name: "Counter",
+		Fields: []*Field{
+			{ID: 1, Name: "count", Type: &testBaseType{name: "i32"}, Default: &testLiteralValue{value: "0"}},
+		},
+	}
+
+	var visited []string
+	Inspect(s, func(n Node) bool {
+		switch n := n.(type) {
+		case *Field:
+			visited = append(visited, "field:"+n.Name)
+		case *testBaseType:
+			visited = append(visited, "type:"+n.name)
+		case *testLiteralValue:
+			visited = append(visited, "value:"+n.value)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{"field:count", "type:i32", "value:0"}, visited)
+}
+
+func TestWalkCustomWalker(t *testing.T) {
+	count := 0
+	enum := &Enum{
+		Name: "Status",
+		Items: []*EnumItem{
+			{Name: "Enabled"},
+			{Name: "Disabled"},
+		},
+	}
+
+	Walk(countingWalker{count: &count}, enum)
+	assert.Equal(t, 3, count) // enum + 2 items
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import "fmt"
+
+// Annotations recognized by this package. Any definition, enum item, or
+// field may carry these; unrecognized annotations are ignored so that
+// other tools (py.name, router.serviceName, ...) can share the same
+// Thrift files without conflicting with thriftrw.
+const (
+	// annotationGoName overrides the identifier thriftrw generates for a
+	// definition, enum item, or field.
+	annotationGoName = "go.name"
+
+	// annotationGoTag causes thriftrw to emit the given string, verbatim,
+	// as the struct tag for a generated field (in place of the default
+	// `json:"name,omitempty"`-style tag), for JSON/YAML/validator
+	// interop.
+	annotationGoTag = "go.tag"
+
+	// annotationGoType substitutes a user-provided Go type for a typedef
+	// or field's generated type, e.g. "time.Time" for a "i64" typedef.
+	annotationGoType = "go.type"
+)
+
+// identifierOverrides tracks the go.name overrides seen so far during
+// code generation for a module, so that collisions can be detected: two
+// definitions, two enum items of the same enum, or two fields of the
+// same struct must not resolve to the same generated identifier.
+type identifierOverrides struct {
+	seen map[string]string // generated identifier -> Thrift-level name that claimed it
+}
+
+func newIdentifierOverrides() *identifierOverrides {
+	return &identifierOverrides{seen: make(map[string]string)}
+}
+
+// claim records that thriftName resolves to goName, returning an error
+// if goName has already been claimed by a different Thrift-level name
+// within the same scope (e.g. the same struct or the same enum).
+func (o *identifierOverrides) claim(thriftName, goName string) error {
+	if existing, ok := o.seen[goName]; ok && existing != thriftName {
+		return fmt.Errorf("go.name annotation collision: %q and %q both resolve to %q", existing, thriftName, goName)
+	}
+	o.seen[goName] = thriftName
+	return nil
+}
+
+// annotations is the subset of compile.Annotations this package reads.
+// compile.Annotations is a map[string]string produced from an ast
+// definition's []*ast.Annotation by the compiler, so a nil map behaves
+// like one with no entries.
+type annotations map[string]string
+
+// goName returns the go.name override for a Thrift-level name, or
+// fallback if no override is present.
+func (a annotations) goName(fallback string) string {
+	if name, ok := a[annotationGoName]; ok && name != "" {
+		return name
+	}
+	return fallback
+}
+
+// goTag returns the go.tag override for a field, or "" if no override is
+// present, in which case the caller should fall back to its default
+// struct tag.
+func (a annotations) goTag() string {
+	return a[annotationGoTag]
+}
+
+// goType returns the go.type override for a typedef or field, and
+// whether one was present.
+func (a annotations) goType() (string, bool) {
+	t, ok := a[annotationGoType]
+	return t, ok
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"testing"
+
+	"go.uber.org/thriftrw/compile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationsGoName(t *testing.T) {
+	a := annotations{"go.name": "UserRole"}
+	assert.Equal(t, "UserRole", a.goName("Role"))
+
+	empty := annotations(nil)
+	assert.Equal(t, "Role", empty.goName("Role"))
+}
+
+func TestAnnotationsGoType(t *testing.T) {
+	a := annotations{"go.type": "time.Time"}
+	typ, ok := a.goType()
+	assert.True(t, ok)
+	assert.Equal(t, "time.Time", typ)
+
+	_, ok = annotations(nil).goType()
+	assert.False(t, ok)
+}
+
+func TestAnnotationsGoTag(t *testing.T) {
+	a := annotations{"go.tag": `json:"id" validate:"required"`}
+	assert.Equal(t, `json:"id" validate:"required"`, a.goTag())
+}
+
+func TestIdentifierOverridesCollision(t *testing.T) {
+	overrides := newIdentifierOverrides()
+
+	require := assert.New(t)
+	require.NoError(overrides.claim("Moderator", "Mod"))
+	require.NoError(overrides.claim("Moderator", "Mod")) // same source, no conflict
+
+	err := overrides.claim("Admin", "Mod")
+	require.Error(err)
+	require.Contains(err.Error(), "Mod")
+}
+
+func TestFieldParamsGoNameAndType(t *testing.T) {
+	fields := compile.FieldGroup{
+		{
+			ThriftName:  "createdAt",
+			Type:        &compile.I64Spec{},
+			Annotations: compile.Annotations{"go.name": "CreatedAt", "go.type": "time.Time"},
+		},
+		{ThriftName: "plain", Type: &compile.StringSpec{}},
+	}
+
+	got, err := fieldParams(fields)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreatedAt time.Time", "plain string"}, got)
+}
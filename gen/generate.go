@@ -0,0 +1,443 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gen provides the code generator that turns a compiled Thrift
+// module into Go source. The templates that render individual Thrift
+// constructs (structs, enums, typedefs, services, ...) live in sibling
+// files in this package; this file owns the orchestration: walking the
+// module graph, deciding what gets written where, and handing off to
+// ServiceGenerator plugins.
+package gen
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/thriftrw/compile"
+	"go.uber.org/thriftrw/internal/plugin"
+	"go.uber.org/thriftrw/plugin/api"
+)
+
+// Options controls the behavior of the code generator.
+type Options struct {
+	// OutputDir is the directory into which generated files are written.
+	// Must be an absolute path.
+	OutputDir string
+
+	// PackagePrefix is the import path prefix prepended to the relative
+	// package path of every generated file.
+	PackagePrefix string
+
+	// ThriftRoot is the directory relative to which the package for a
+	// Thrift file is calculated. Must be an absolute path.
+	ThriftRoot string
+
+	// OutputFile overrides the name of the file that the Thrift
+	// definitions for a module are written to. Defaults to the base name
+	// of the module's package.
+	OutputFile string
+
+	// NoRecurse disables code generation for included Thrift files.
+	NoRecurse bool
+
+	// Plugin is an optional plugin.Handle used to generate additional,
+	// user-defined code (such as service clients/handlers) alongside the
+	// types generated by this package.
+	Plugin plugin.Handle
+
+	// ContextAware, when true, threads a context.Context as the first
+	// argument of every generated service client method and handler
+	// method, and is reported to ServiceGenerator plugins so that they
+	// can match the signature. This defaults to false for now to avoid
+	// breaking existing generated code; it will default to true in a
+	// future major version.
+	ContextAware bool
+
+	// UseTypeAliases, when true, causes typedefs whose target is a
+	// primitive, another typedef, a struct, or a container type to be
+	// generated as Go type aliases (type Foo = Bar) instead of distinct
+	// named types (type Foo Bar). A typedef can opt into this behavior
+	// individually with the "go.alias" annotation even when this option
+	// is false.
+	UseTypeAliases bool
+
+	// Concurrency bounds the number of modules rendered in parallel.
+	// Defaults to runtime.GOMAXPROCS(0) when zero or negative.
+	Concurrency int
+
+	// Check, when true, does not write any files. Instead, Generate
+	// fails if generation would change any file already present on disk
+	// under OutputDir, or would write a file that isn't there yet. This
+	// is suitable for CI gating of committed generated code.
+	Check bool
+}
+
+// Generate generates code for the given compiled Thrift module and, if
+// requested, recursively for all modules that it includes.
+//
+// Modules are rendered into an in-memory file map first, in parallel
+// across up to Options.Concurrency workers; only once every module (and
+// any ServiceGenerator plugin) has finished does Generate touch disk, so
+// that a failure partway through never leaves a half-written tree.
+// Content that already matches what's on disk is left untouched, so
+// output hashes (and mtimes) are stable across repeated invocations that
+// produce the same bytes. See writeFiles and Options.Check.
+func Generate(m *compile.Module, o *Options) error {
+	if !filepath.IsAbs(o.OutputDir) {
+		return fmt.Errorf("OutputDir must be an absolute path: %q", o.OutputDir)
+	}
+	if !filepath.IsAbs(o.ThriftRoot) {
+		return fmt.Errorf("ThriftRoot must be an absolute path: %q", o.ThriftRoot)
+	}
+
+	importer := thriftPackageImporter{
+		ImportPrefix: o.PackagePrefix,
+		ThriftRoot:   o.ThriftRoot,
+	}
+
+	modules, err := collectModules(m, o.NoRecurse)
+	if err != nil {
+		return err
+	}
+
+	results := make([]moduleResult, len(modules))
+	if err := parallelEach(len(modules), o.Concurrency, func(i int) error {
+		moduleFiles, info, err := generateModule(modules[i], importer, o)
+		if err != nil {
+			return fmt.Errorf("could not generate %q: %v", modules[i].ThriftPath, err)
+		}
+		results[i] = moduleResult{files: moduleFiles, info: info}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	files := make(map[string][]byte)
+	genBuilder := newGenerateServiceBuilder(importer)
+	for _, r := range results {
+		for relPath, contents := range r.files {
+			if err := addFile(files, relPath, contents); err != nil {
+				return err
+			}
+		}
+		genBuilder.merge(r.info)
+	}
+
+	if o.Plugin != nil {
+		if sgen := o.Plugin.ServiceGenerator(); sgen != nil {
+			req := genBuilder.Build()
+			req.ContextAware = o.ContextAware
+
+			res, err := sgen.Generate(req)
+			if err != nil {
+				return err
+			}
+			for relPath, contents := range res.Files {
+				if err := addFile(files, relPath, contents); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return writeFiles(files, o)
+}
+
+// collectModules returns m and, unless noRecurse is set, every module
+// reachable from m via includes, each exactly once, in a deterministic
+// order (sorted by Thrift file path) so that parallel generation always
+// schedules work the same way regardless of map iteration order.
+func collectModules(m *compile.Module, noRecurse bool) ([]*compile.Module, error) {
+	seen := make(map[*compile.Module]struct{})
+	var modules []*compile.Module
+
+	var visit func(*compile.Module) error
+	visit = func(mod *compile.Module) error {
+		if _, ok := seen[mod]; ok {
+			return nil
+		}
+		seen[mod] = struct{}{}
+		modules = append(modules, mod)
+
+		if noRecurse {
+			return nil
+		}
+		for _, included := range mod.Includes {
+			if err := visit(included.Module); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(m); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].ThriftPath < modules[j].ThriftPath
+	})
+	return modules, nil
+}
+
+// moduleResult is the output of rendering a single module: its files,
+// plus the service/module metadata that will be merged into the shared
+// generateServiceBuilder once every module has finished rendering.
+type moduleResult struct {
+	files map[string][]byte
+	info  *moduleServiceInfo
+}
+
+// parallelEach calls f(i) for each i in [0, n) using up to concurrency
+// workers, returning the first error encountered. concurrency <= 0 means
+// runtime.GOMAXPROCS(0).
+func parallelEach(n, concurrency int, f func(i int) error) error {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	work := make(chan int)
+	errs := make(chan error, n)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				errs <- f(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFile records that relPath should be written with the given
+// contents, failing if a different source has already claimed that path.
+// Two sources writing byte-identical content to the same path is not a
+// conflict; it is silently merged.
+func addFile(files map[string][]byte, relPath string, contents []byte) error {
+	relPath = filepath.ToSlash(relPath)
+	if existing, ok := files[relPath]; ok {
+		if string(existing) == string(contents) {
+			return nil
+		}
+		return fmt.Errorf("file generation conflict: multiple sources are trying to write to %q", relPath)
+	}
+	files[relPath] = contents
+	return nil
+}
+
+// thriftPackageImporter determines the Go package that generated code for
+// a Thrift file belongs in.
+type thriftPackageImporter struct {
+	// ImportPrefix is prepended to the relative package of a Thrift file
+	// to determine its full import path.
+	ImportPrefix string
+
+	// ThriftRoot is the directory relative to which Thrift files are
+	// resolved into packages.
+	ThriftRoot string
+}
+
+// RelativePackage returns the slash-separated package path of the given
+// Thrift file, relative to ThriftRoot.
+func (i thriftPackageImporter) RelativePackage(thriftFile string) (string, error) {
+	path, err := filepath.Rel(i.ThriftRoot, thriftFile)
+	if err != nil {
+		return "", fmt.Errorf("could not relativize path %q: %v", thriftFile, err)
+	}
+
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return filepath.ToSlash(filepath.Join(dir, base)), nil
+}
+
+// Package returns the full import path of the package that the given
+// Thrift file's generated code belongs in.
+func (i thriftPackageImporter) Package(thriftFile string) (string, error) {
+	pkg, err := i.RelativePackage(thriftFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(filepath.Join(i.ImportPrefix, pkg)), nil
+}
+
+// ServiceName returns the generated identifier for svc, honoring any
+// go.name annotation override. ServiceGenerator plugins receive services
+// through the api.Service values built by generateModule, which take
+// their Name from this method, so plugins never see a Thrift-level name
+// that diverges from what thriftrw itself generated.
+func (i thriftPackageImporter) ServiceName(svc *compile.ServiceSpec) string {
+	return annotations(svc.Annotations).goName(svc.Name)
+}
+
+// generateServiceBuilder accumulates the information that will be handed
+// to a ServiceGenerator plugin once every module has been visited.
+type generateServiceBuilder struct {
+	importer thriftPackageImporter
+
+	RootServices []*api.Service
+	Services     map[string]*api.Service
+	Modules      map[string]*api.Module
+}
+
+func newGenerateServiceBuilder(importer thriftPackageImporter) *generateServiceBuilder {
+	return &generateServiceBuilder{
+		importer: importer,
+		Services: make(map[string]*api.Service),
+		Modules:  make(map[string]*api.Module),
+	}
+}
+
+// Build finalizes the accumulated state into a GenerateServiceRequest for
+// a ServiceGenerator plugin.
+func (b *generateServiceBuilder) Build() *api.GenerateServiceRequest {
+	return &api.GenerateServiceRequest{
+		RootServices: b.RootServices,
+		Services:     b.Services,
+		Modules:      b.Modules,
+	}
+}
+
+// merge folds a single module's service/module metadata into b. Callers
+// must merge results in the same deterministic order collectModules
+// produced them in, so that RootServices ends up in a stable order
+// regardless of how generateModule calls were scheduled across workers.
+func (b *generateServiceBuilder) merge(info *moduleServiceInfo) {
+	if info == nil {
+		return
+	}
+	for pkg, mod := range info.modules {
+		b.Modules[pkg] = mod
+	}
+	for key, svc := range info.services {
+		b.Services[key] = svc
+	}
+	b.RootServices = append(b.RootServices, info.rootServices...)
+}
+
+// moduleServiceInfo is the service/module metadata generateModule
+// extracts from a single compiled module, for later, single-threaded
+// merging into a generateServiceBuilder.
+type moduleServiceInfo struct {
+	modules      map[string]*api.Module
+	services     map[string]*api.Service
+	rootServices []*api.Service
+}
+
+// generateModule renders the Go source for a single compiled module (not
+// including any modules that it includes) and extracts its service
+// metadata for later merging into a generateServiceBuilder.
+//
+// generateModule touches no shared state and is safe to call
+// concurrently for distinct modules, which is what parallelEach does in
+// Generate.
+//
+// It returns the rendered files, keyed by path relative to OutputDir.
+func generateModule(m *compile.Module, importer thriftPackageImporter, o *Options) (map[string][]byte, *moduleServiceInfo, error) {
+	pkg, err := importer.RelativePackage(m.ThriftPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputFile := o.OutputFile
+	if outputFile == "" {
+		outputFile = filepath.Base(pkg) + ".go"
+	}
+
+	// The rendering of the module's Go source is done by renderModule;
+	// generateModule is responsible only for choosing the module's output
+	// path and collecting its service metadata for later merging.
+	contents, err := renderModule(m, importer, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := map[string][]byte{
+		filepath.ToSlash(filepath.Join(pkg, outputFile)): contents,
+	}
+
+	modPkg, err := importer.Package(m.ThriftPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := &moduleServiceInfo{
+		modules:  map[string]*api.Module{modPkg: {Name: m.Name, ImportPath: modPkg}},
+		services: make(map[string]*api.Service, len(m.Services)),
+	}
+
+	// A module's direct includes need to be in the merged Modules map
+	// even when Options.NoRecurse means we never separately call
+	// generateModule for them: a ServiceGenerator plugin may still need
+	// their import paths to reference types defined there (e.g. a field
+	// whose type lives in an included module).
+	for _, included := range m.Includes {
+		incPkg, err := importer.Package(included.Module.ThriftPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		info.modules[incPkg] = &api.Module{Name: included.Module.Name, ImportPath: incPkg}
+	}
+
+	serviceNames := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	for _, name := range serviceNames {
+		svc := m.Services[name]
+		apiSvc := &api.Service{
+			Name:        importer.ServiceName(svc),
+			ModuleName:  m.Name,
+			PackageName: modPkg,
+		}
+		info.services[modPkg+"."+name] = apiSvc
+		if svc.Parent == nil {
+			info.rootServices = append(info.rootServices, apiSvc)
+		}
+	}
+
+	return files, info, nil
+}
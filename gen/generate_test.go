@@ -283,17 +283,57 @@ func TestGenerateModule(t *testing.T) {
 			ThriftRoot:    thriftRoot,
 		}
 
-		_, _, err = generateModule(module, importer, genBuilder, opt)
+		_, info, err := generateModule(module, importer, opt)
 		require.NoError(t, err)
+		genBuilder.merge(info)
 
 		gen := genBuilder.Build()
 
 		assert.Equal(t, len(gen.RootServices), 0)
 		assert.Equal(t, len(gen.Services), 0)
+		// 2: structs.thrift itself, plus the one module it directly
+		// includes. generateModule records both so that a
+		// ServiceGenerator plugin has the included module's import path
+		// available even when Options.NoRecurse keeps Generate from
+		// separately visiting it.
 		assert.Equal(t, len(gen.Modules), 2)
 	})
 }
 
+func TestGenerateModuleRegistersDirectIncludes(t *testing.T) {
+	thriftRoot := testdata(t, "thrift")
+	importer := thriftPackageImporter{
+		ImportPrefix: "go.uber.org/thriftrw/gen/internal/tests",
+		ThriftRoot:   thriftRoot,
+	}
+
+	included := &compile.Module{
+		Name:       "bar",
+		ThriftPath: testdata(t, "thrift/common/bar.thrift"),
+	}
+	m := &compile.Module{
+		Name:       "foo",
+		ThriftPath: testdata(t, "thrift/foo.thrift"),
+		Includes: map[string]*compile.IncludedModule{
+			"bar": {Name: "bar", Module: included},
+		},
+	}
+
+	_, info, err := generateModule(m, importer, &Options{
+		OutputDir:     "test/internal",
+		PackagePrefix: "go.uber.org/thriftrw/gen",
+		ThriftRoot:    thriftRoot,
+		NoRecurse:     true,
+	})
+	require.NoError(t, err)
+
+	genBuilder := newGenerateServiceBuilder(importer)
+	genBuilder.merge(info)
+	gen := genBuilder.Build()
+
+	assert.Len(t, gen.Modules, 2, "foo itself plus its direct include bar, even with NoRecurse")
+}
+
 func TestThriftPackageImporter(t *testing.T) {
 	importer := thriftPackageImporter{
 		ImportPrefix: "github.com/myteam/myservice",
@@ -0,0 +1,284 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"go.uber.org/thriftrw/compile"
+)
+
+// renderModule renders the Go source for a single compiled module. Struct,
+// enum, and typedef rendering live in sibling template files (struct.go,
+// typedef.go); this function owns the parts of the module (the package
+// clause, the service client/handler interfaces, and the ordering of
+// everything else) that are affected by Options.
+func renderModule(m *compile.Module, importer thriftPackageImporter, o *Options) ([]byte, error) {
+	var body bytes.Buffer
+	imports := newImportSet()
+
+	serviceNames := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	overrides := newIdentifierOverrides()
+	for _, serviceName := range serviceNames {
+		svc := m.Services[serviceName]
+		name := annotations(svc.Annotations).goName(svc.Name)
+		if err := overrides.claim(svc.Name, name); err != nil {
+			return nil, err
+		}
+		if err := renderServiceInterfaces(&body, svc, o, imports); err != nil {
+			return nil, err
+		}
+	}
+
+	typeNames := make([]string, 0, len(m.Types))
+	for name := range m.Types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	for _, name := range typeNames {
+		switch t := m.Types[name].(type) {
+		case *compile.StructSpec:
+			rendered, err := renderStruct(name, t, o)
+			if err != nil {
+				return nil, err
+			}
+			body.WriteString(rendered)
+		case *compile.EnumSpec:
+			rendered, err := renderEnum(name, t)
+			if err != nil {
+				return nil, err
+			}
+			body.WriteString(rendered)
+		case *compile.TypedefSpec:
+			body.WriteString(renderTypedef(name, t, o))
+			if methods := renderTypedefMethods(name, t, o); methods != "" {
+				imports.add("fmt")
+				body.WriteString(methods)
+			}
+		}
+	}
+
+	pkg, err := importer.RelativePackage(m.ThriftPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by thriftrw. DO NOT EDIT.\n// @generated\n\npackage %s\n", packageName(pkg))
+	imports.render(&buf)
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// importSet tracks, in first-requested order, the set of packages a
+// rendered file needs to import, so that renderModule can emit a single
+// import block after the package clause rather than each renderer having
+// to know where in the file it is.
+type importSet struct {
+	seen  map[string]struct{}
+	paths []string
+}
+
+func newImportSet() *importSet {
+	return &importSet{seen: make(map[string]struct{})}
+}
+
+// add records that the rendered file needs to import path. Safe to call
+// more than once with the same path.
+func (s *importSet) add(path string) {
+	if _, ok := s.seen[path]; ok {
+		return
+	}
+	s.seen[path] = struct{}{}
+	s.paths = append(s.paths, path)
+}
+
+// render writes an import block for every path added to s, or nothing if
+// s is empty.
+func (s *importSet) render(buf *bytes.Buffer) {
+	if len(s.paths) == 0 {
+		return
+	}
+	paths := append([]string(nil), s.paths...)
+	sort.Strings(paths)
+
+	buf.WriteString("\nimport (\n")
+	for _, path := range paths {
+		fmt.Fprintf(buf, "\t%q\n", path)
+	}
+	buf.WriteString(")\n")
+}
+
+// renderServiceInterfaces renders the client and handler interfaces for a
+// single service, in declaration order matching the Thrift file, naming
+// the generated types after the service itself so that a module with
+// more than one service does not collide on a single "Interface"/
+// "Handler" pair.
+func renderServiceInterfaces(buf *bytes.Buffer, svc *compile.ServiceSpec, o *Options, imports *importSet) error {
+	name := annotations(svc.Annotations).goName(svc.Name)
+	if o.ContextAware && len(svc.Functions) > 0 {
+		imports.add("context")
+	}
+
+	fmt.Fprintf(buf, "\n// %sInterface is the client-facing interface for the %s service.\ntype %sInterface interface {\n", name, name, name)
+	for _, fn := range svc.Functions {
+		sig, err := clientMethodSignature(fn, o)
+		if err != nil {
+			return fmt.Errorf("service %s: %v", svc.Name, err)
+		}
+		fmt.Fprintf(buf, "\t%s\n", sig)
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(buf, "\n// %sHandler is the handler-facing interface for the %s service.\ntype %sHandler interface {\n", name, name, name)
+	for _, fn := range svc.Functions {
+		sig, err := handlerMethodSignature(fn, o)
+		if err != nil {
+			return fmt.Errorf("service %s: %v", svc.Name, err)
+		}
+		fmt.Fprintf(buf, "\t%s\n", sig)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// clientMethodSignature renders a single client method signature for fn,
+// threading a leading ctx context.Context parameter when o.ContextAware is
+// set. This applies equally to oneway and regular functions, and to
+// functions inherited from a Parent service reference, since those are
+// compiled onto the same ServiceSpec.Functions list as locally declared
+// ones.
+//
+// It fails if two of fn's parameters resolve to the same Go identifier
+// after go.name overrides are applied.
+func clientMethodSignature(fn *compile.FunctionSpec, o *Options) (string, error) {
+	name := annotations(fn.Annotations).goName(fn.Name)
+
+	params, err := fieldParams(fn.ArgsSpec)
+	if err != nil {
+		return "", fmt.Errorf("function %s: %v", fn.Name, err)
+	}
+	if o.ContextAware {
+		params = append([]string{"ctx context.Context"}, params...)
+	}
+
+	ret := "error"
+	if fn.ResultSpec != nil && fn.ResultSpec.ReturnType != nil && !fn.OneWay {
+		ret = fmt.Sprintf("(%s, error)", typeName(fn.ResultSpec.ReturnType))
+	}
+	return fmt.Sprintf("%s(%s) %s", name, joinParams(params), ret), nil
+}
+
+// handlerMethodSignature mirrors clientMethodSignature for the
+// server-side handler interface. Handlers always receive the same
+// leading ctx parameter as the client so that dispatch can propagate
+// deadlines and tracing metadata straight through.
+func handlerMethodSignature(fn *compile.FunctionSpec, o *Options) (string, error) {
+	return clientMethodSignature(fn, o)
+}
+
+// fieldParams renders a FieldGroup as "name Type" parameter strings,
+// honoring any go.name/go.type annotations on the individual fields. It
+// fails if two fields in the group resolve to the same Go identifier,
+// e.g. because a go.name override on one collides with another field's
+// default or overridden name.
+func fieldParams(fields compile.FieldGroup) ([]string, error) {
+	overrides := newIdentifierOverrides()
+	params := make([]string, 0, len(fields))
+	for _, f := range fields {
+		name := annotations(f.Annotations).goName(f.ThriftName)
+		if err := overrides.claim(f.ThriftName, name); err != nil {
+			return nil, err
+		}
+
+		typ := typeName(f.Type)
+		if override, ok := annotations(f.Annotations).goType(); ok {
+			typ = override
+		}
+
+		params = append(params, fmt.Sprintf("%s %s", name, typ))
+	}
+	return params, nil
+}
+
+func joinParams(params []string) string {
+	out := ""
+	for i, p := range params {
+		if i > 0 {
+			out += ", "
+		}
+		out += p
+	}
+	return out
+}
+
+// typeName renders the Go type used to represent a compiled Thrift type.
+// The full mapping (containers, typedefs, structs by reference, ...)
+// lives in the (larger) sibling files not included in this chunk of the
+// tree; this covers the primitives plus the cases this chunk's tests
+// exercise directly.
+func typeName(t compile.TypeSpec) string {
+	switch t.(type) {
+	case *compile.BoolSpec:
+		return "bool"
+	case *compile.I8Spec:
+		return "int8"
+	case *compile.I16Spec:
+		return "int16"
+	case *compile.I32Spec:
+		return "int32"
+	case *compile.I64Spec:
+		return "int64"
+	case *compile.DoubleSpec:
+		return "float64"
+	case *compile.StringSpec:
+		return "string"
+	case *compile.BinarySpec:
+		return "[]byte"
+	case nil:
+		return "struct{}"
+	default:
+		return t.ThriftName()
+	}
+}
+
+// packageName is a placeholder for the real package-naming logic, which
+// lives in the (larger) sibling files not included in this chunk of the
+// tree.
+
+func packageName(relPkg string) string {
+	base := relPkg
+	for i := len(base) - 1; i >= 0; i-- {
+		if base[i] == '/' {
+			return base[i+1:]
+		}
+	}
+	return base
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/thriftrw/compile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientMethodSignatureContextAware(t *testing.T) {
+	regular := &compile.FunctionSpec{
+		Name:       "getValue",
+		ArgsSpec:   compile.FieldGroup{{ThriftName: "key", Type: &compile.StringSpec{}}},
+		ResultSpec: &compile.ResultSpec{ReturnType: &compile.StringSpec{}},
+	}
+	oneway := &compile.FunctionSpec{
+		Name:     "setValue",
+		ArgsSpec: compile.FieldGroup{{ThriftName: "key", Type: &compile.StringSpec{}}},
+		OneWay:   true,
+	}
+	inherited := &compile.FunctionSpec{
+		Name:       "ping",
+		ArgsSpec:   compile.FieldGroup{},
+		ResultSpec: &compile.ResultSpec{},
+	}
+
+	tests := []struct {
+		desc string
+		fn   *compile.FunctionSpec
+		want string
+	}{
+		{"regular, context-aware", regular, "getValue(ctx context.Context, key string) (string, error)"},
+		{"oneway, context-aware", oneway, "setValue(ctx context.Context, key string) error"},
+		{"inherited from Parent, context-aware", inherited, "ping(ctx context.Context) error"},
+	}
+
+	for _, tt := range tests {
+		got, err := clientMethodSignature(tt.fn, &Options{ContextAware: true})
+		require.NoError(t, err, tt.desc)
+		assert.Equal(t, tt.want, got, tt.desc)
+	}
+}
+
+func TestClientMethodSignatureNotContextAware(t *testing.T) {
+	fn := &compile.FunctionSpec{
+		Name:       "getValue",
+		ArgsSpec:   compile.FieldGroup{{ThriftName: "key", Type: &compile.StringSpec{}}},
+		ResultSpec: &compile.ResultSpec{ReturnType: &compile.StringSpec{}},
+	}
+
+	got, err := clientMethodSignature(fn, &Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "getValue(key string) (string, error)", got)
+}
+
+func TestHandlerMethodSignatureMatchesClient(t *testing.T) {
+	fn := &compile.FunctionSpec{
+		Name:     "setValue",
+		ArgsSpec: compile.FieldGroup{{ThriftName: "key", Type: &compile.StringSpec{}}},
+		OneWay:   true,
+	}
+
+	opts := &Options{ContextAware: true}
+	client, err := clientMethodSignature(fn, opts)
+	require.NoError(t, err)
+	handler, err := handlerMethodSignature(fn, opts)
+	require.NoError(t, err)
+	assert.Equal(t, client, handler)
+}
+
+func TestFieldParamsCollision(t *testing.T) {
+	fields := compile.FieldGroup{
+		{ThriftName: "key", Type: &compile.StringSpec{}},
+		{ThriftName: "value", Type: &compile.StringSpec{}, Annotations: compile.Annotations{"go.name": "key"}},
+	}
+
+	_, err := fieldParams(fields)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "key")
+}
+
+func TestRenderServiceInterfacesNamesPerService(t *testing.T) {
+	foo := &compile.ServiceSpec{Name: "Foo", Functions: []*compile.FunctionSpec{
+		{Name: "get", ArgsSpec: compile.FieldGroup{}, ResultSpec: &compile.ResultSpec{}},
+	}}
+	bar := &compile.ServiceSpec{Name: "Bar", Functions: []*compile.FunctionSpec{
+		{Name: "set", ArgsSpec: compile.FieldGroup{}, ResultSpec: &compile.ResultSpec{}},
+	}}
+
+	var buf bytes.Buffer
+	imports := newImportSet()
+	require.NoError(t, renderServiceInterfaces(&buf, foo, &Options{}, imports))
+	require.NoError(t, renderServiceInterfaces(&buf, bar, &Options{}, imports))
+
+	out := buf.String()
+	assert.Contains(t, out, "type FooInterface interface {")
+	assert.Contains(t, out, "type FooHandler interface {")
+	assert.Contains(t, out, "type BarInterface interface {")
+	assert.Contains(t, out, "type BarHandler interface {")
+	assert.NotContains(t, out, "type Interface interface {")
+	assert.NotContains(t, out, "type Handler interface {")
+}
+
+func TestRenderServiceInterfacesImportsContext(t *testing.T) {
+	svc := &compile.ServiceSpec{Name: "Foo", Functions: []*compile.FunctionSpec{
+		{Name: "get", ArgsSpec: compile.FieldGroup{}, ResultSpec: &compile.ResultSpec{}},
+	}}
+
+	var buf bytes.Buffer
+	imports := newImportSet()
+	require.NoError(t, renderServiceInterfaces(&buf, svc, &Options{ContextAware: true}, imports))
+
+	var importBuf bytes.Buffer
+	imports.render(&importBuf)
+	assert.Contains(t, importBuf.String(), `"context"`)
+}
+
+func TestRenderModuleTwoServicesNoCollision(t *testing.T) {
+	m := &compile.Module{
+		ThriftPath: "/root/thrift/foo.thrift",
+		Services: map[string]*compile.ServiceSpec{
+			"Foo": {Name: "Foo", Functions: []*compile.FunctionSpec{
+				{Name: "get", ArgsSpec: compile.FieldGroup{}, ResultSpec: &compile.ResultSpec{}},
+			}},
+			"Bar": {Name: "Bar", Functions: []*compile.FunctionSpec{
+				{Name: "set", ArgsSpec: compile.FieldGroup{}, ResultSpec: &compile.ResultSpec{}},
+			}},
+		},
+	}
+	importer := thriftPackageImporter{ImportPrefix: "go.uber.org/thriftrw/gen", ThriftRoot: "/root/thrift"}
+
+	contents, err := renderModule(m, importer, &Options{ContextAware: true})
+	assert.NoError(t, err)
+
+	out := string(contents)
+	assert.Contains(t, out, "type FooInterface interface {")
+	assert.Contains(t, out, "type BarInterface interface {")
+	assert.Contains(t, out, `"context"`)
+	assert.Equal(t, 1, bytes.Count(contents, []byte("import (")))
+}
+
+func TestRenderModuleRendersTypedefs(t *testing.T) {
+	m := &compile.Module{
+		ThriftPath: "/root/thrift/foo.thrift",
+		Types: map[string]compile.TypeSpec{
+			"Timestamp": &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}},
+		},
+	}
+	importer := thriftPackageImporter{ImportPrefix: "go.uber.org/thriftrw/gen", ThriftRoot: "/root/thrift"}
+
+	contents, err := renderModule(m, importer, &Options{UseTypeAliases: true})
+	require.NoError(t, err)
+	out := string(contents)
+	assert.Contains(t, out, "type Timestamp = int64")
+	assert.NotContains(t, out, "String() string", "aliased typedefs must not get a method receiver")
+	assert.NotContains(t, out, `"fmt"`)
+}
+
+func TestRenderModuleRendersTypedefMethods(t *testing.T) {
+	m := &compile.Module{
+		ThriftPath: "/root/thrift/foo.thrift",
+		Types: map[string]compile.TypeSpec{
+			"Timestamp": &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}},
+		},
+	}
+	importer := thriftPackageImporter{ImportPrefix: "go.uber.org/thriftrw/gen", ThriftRoot: "/root/thrift"}
+
+	contents, err := renderModule(m, importer, &Options{})
+	require.NoError(t, err)
+	out := string(contents)
+	assert.Contains(t, out, "type Timestamp int64")
+	assert.Contains(t, out, "func (v Timestamp) String() string {")
+	assert.Contains(t, out, `"fmt"`)
+}
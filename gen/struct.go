@@ -0,0 +1,87 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+
+	"go.uber.org/thriftrw/compile"
+)
+
+// renderStruct renders the Go struct declaration for a single compiled
+// struct, union, or exception, honoring go.name/go.type overrides on its
+// fields the same way fieldParams does for function parameters, and a
+// go.tag override for the struct tag emitted on each field in place of
+// the default `json:"name,omitempty"` tag.
+//
+// It fails if two fields resolve to the same Go identifier after go.name
+// overrides are applied.
+func renderStruct(name string, ss *compile.StructSpec, o *Options) (string, error) {
+	name = annotations(ss.Annotations).goName(name)
+
+	overrides := newIdentifierOverrides()
+	var body bytes.Buffer
+	for _, f := range ss.Fields {
+		fieldName := annotations(f.Annotations).goName(f.ThriftName)
+		if err := overrides.claim(f.ThriftName, fieldName); err != nil {
+			return "", fmt.Errorf("struct %s: %v", name, err)
+		}
+
+		typ := typeName(f.Type)
+		if override, ok := annotations(f.Annotations).goType(); ok {
+			typ = override
+		}
+
+		tag := annotations(f.Annotations).goTag()
+		if tag == "" {
+			tag = fmt.Sprintf(`json:"%s,omitempty"`, f.ThriftName)
+		}
+
+		fmt.Fprintf(&body, "\t%s %s `%s`\n", fieldName, typ, tag)
+	}
+
+	return fmt.Sprintf("\ntype %s struct {\n%s}\n", name, body.String()), nil
+}
+
+// renderEnum renders the Go type and constant declarations for a single
+// compiled enum, honoring go.name overrides on its items the same way
+// fieldParams does for function parameters.
+//
+// It fails if two items resolve to the same Go identifier after go.name
+// overrides are applied.
+func renderEnum(name string, es *compile.EnumSpec) (string, error) {
+	name = annotations(es.Annotations).goName(name)
+
+	overrides := newIdentifierOverrides()
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "\ntype %s int32\n\nconst (\n", name)
+	for _, item := range es.Items {
+		itemName := annotations(item.Annotations).goName(item.Name)
+		if err := overrides.claim(item.Name, itemName); err != nil {
+			return "", fmt.Errorf("enum %s: %v", name, err)
+		}
+		fmt.Fprintf(&body, "\t%s%s %s = %d\n", name, itemName, name, item.Value)
+	}
+	body.WriteString(")\n")
+
+	return body.String(), nil
+}
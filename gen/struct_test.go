@@ -0,0 +1,88 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"testing"
+
+	"go.uber.org/thriftrw/compile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderStructGoTagOverride(t *testing.T) {
+	ss := &compile.StructSpec{
+		Name: "User",
+		Fields: compile.FieldGroup{
+			{ThriftName: "id", Type: &compile.I64Spec{}, Annotations: compile.Annotations{"go.tag": `json:"id" validate:"required"`}},
+			{ThriftName: "name", Type: &compile.StringSpec{}},
+		},
+	}
+
+	got, err := renderStruct("User", ss, &Options{})
+	require.NoError(t, err)
+	assert.Contains(t, got, "type User struct {")
+	assert.Contains(t, got, "id int64 `json:\"id\" validate:\"required\"`")
+	assert.Contains(t, got, "name string `json:\"name,omitempty\"`")
+}
+
+func TestRenderStructFieldCollision(t *testing.T) {
+	ss := &compile.StructSpec{
+		Name: "User",
+		Fields: compile.FieldGroup{
+			{ThriftName: "id", Type: &compile.I64Spec{}},
+			{ThriftName: "identifier", Type: &compile.I64Spec{}, Annotations: compile.Annotations{"go.name": "id"}},
+		},
+	}
+
+	_, err := renderStruct("User", ss, &Options{})
+	require.Error(t, err)
+}
+
+func TestRenderEnumItemCollision(t *testing.T) {
+	es := &compile.EnumSpec{
+		Name: "Status",
+		Items: []compile.EnumItem{
+			{Name: "Enabled", Value: 0},
+			{Name: "Active", Value: 1, Annotations: compile.Annotations{"go.name": "Enabled"}},
+		},
+	}
+
+	_, err := renderEnum("Status", es)
+	require.Error(t, err)
+}
+
+func TestRenderEnumNoCollision(t *testing.T) {
+	es := &compile.EnumSpec{
+		Name: "Status",
+		Items: []compile.EnumItem{
+			{Name: "Enabled", Value: 0},
+			{Name: "Disabled", Value: 1},
+		},
+	}
+
+	got, err := renderEnum("Status", es)
+	require.NoError(t, err)
+	assert.Contains(t, got, "type Status int32")
+	assert.Contains(t, got, "StatusEnabled Status = 0")
+	assert.Contains(t, got, "StatusDisabled Status = 1")
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"fmt"
+
+	"go.uber.org/thriftrw/compile"
+)
+
+// typedefAliasAnnotation is the Thrift annotation that opts a single
+// typedef into alias generation when Options.UseTypeAliases is not set
+// globally.
+const typedefAliasAnnotation = "go.alias"
+
+// shouldAliasTypedef reports whether the typedef ts should be rendered as
+// a Go type alias (type Foo = Bar) rather than a distinct named type
+// (type Foo Bar).
+//
+// Every typedef is aliased when Options.UseTypeAliases is set, or when it
+// carries the go.alias annotation with the value "true". The typedef's
+// target type is not inspected.
+func shouldAliasTypedef(ts *compile.TypedefSpec, o *Options) bool {
+	if o.UseTypeAliases {
+		return true
+	}
+	if ts.Annotations == nil {
+		return false
+	}
+	return ts.Annotations[typedefAliasAnnotation] == "true"
+}
+
+// renderTypedef renders the Go source for a single typedef. When the
+// typedef is aliasable (see shouldAliasTypedef), it is emitted as a Go
+// 1.9+ type alias so that it is assignable with, and convertible from,
+// its target type without an explicit conversion. A *Ptr convenience
+// constructor is still emitted in either case, since aliasing does not
+// change how callers construct pointers to the type.
+//
+// Go forbids declaring methods on an alias to a type defined in another
+// package, so renderModule calls renderTypedefMethods, not this
+// function, to decide whether to additionally emit a String() method;
+// see typedefHasMethods.
+func renderTypedef(name string, ts *compile.TypedefSpec, o *Options) string {
+	name = annotations(ts.Annotations).goName(name)
+
+	target := typeName(ts.Target)
+	if override, ok := annotations(ts.Annotations).goType(); ok {
+		// A go.type override substitutes a user-provided Go type (e.g.
+		// time.Time for an i64 typedef) in place of the type thriftrw
+		// would otherwise generate. Marshaling between the Thrift wire
+		// representation and the override type is handled by
+		// reader/writer methods emitted alongside this typedef, which
+		// live in the sibling files not included in this chunk.
+		target = override
+	}
+
+	if shouldAliasTypedef(ts, o) {
+		return fmt.Sprintf("type %s = %s\n\nfunc %sPtr(v %s) *%s { return &v }\n", name, target, name, name, name)
+	}
+	return fmt.Sprintf("type %s %s\n\nfunc %sPtr(v %s) *%s { return &v }\n", name, target, name, name, name)
+}
+
+// typedefHasMethods reports whether readers/writers and other methods
+// should be generated with name as the receiver. It is false for
+// typedefs rendered as aliases, since Go does not allow methods to be
+// declared on an alias to a type defined outside the current package
+// (and, for aliases to local types, the target's own methods already
+// apply).
+func typedefHasMethods(ts *compile.TypedefSpec, o *Options) bool {
+	return !shouldAliasTypedef(ts, o)
+}
+
+// renderTypedefMethods renders the methods declared with the typedef's
+// generated type as their receiver, or "" if typedefHasMethods reports
+// that ts was rendered as an alias. The full reader/writer pair lives in
+// the (larger) sibling files not included in this chunk of the tree;
+// String() is the one method this chunk generates directly, so that
+// typedefHasMethods has a real call site gating it rather than only its
+// own unit test.
+func renderTypedefMethods(name string, ts *compile.TypedefSpec, o *Options) string {
+	if !typedefHasMethods(ts, o) {
+		return ""
+	}
+	name = annotations(ts.Annotations).goName(name)
+
+	underlying := typeName(ts.Target)
+	if override, ok := annotations(ts.Annotations).goType(); ok {
+		underlying = override
+	}
+
+	return fmt.Sprintf("\nfunc (v %s) String() string {\n\treturn fmt.Sprintf(\"%%v\", %s(v))\n}\n", name, underlying)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"testing"
+
+	"go.uber.org/thriftrw/compile"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldAliasTypedef(t *testing.T) {
+	tests := []struct {
+		desc string
+		ts   *compile.TypedefSpec
+		opts *Options
+		want bool
+	}{
+		{
+			desc: "UseTypeAliases set, no annotation",
+			ts:   &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}},
+			opts: &Options{UseTypeAliases: true},
+			want: true,
+		},
+		{
+			desc: "UseTypeAliases unset, no annotation",
+			ts:   &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}},
+			opts: &Options{},
+			want: false,
+		},
+		{
+			desc: "UseTypeAliases unset, go.alias annotation",
+			ts: &compile.TypedefSpec{
+				Name:        "Timestamp",
+				Target:      &compile.I64Spec{},
+				Annotations: compile.Annotations{"go.alias": "true"},
+			},
+			opts: &Options{},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, shouldAliasTypedef(tt.ts, tt.opts), tt.desc)
+	}
+}
+
+func TestRenderTypedefAlias(t *testing.T) {
+	ts := &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}}
+
+	got := renderTypedef("Timestamp", ts, &Options{UseTypeAliases: true})
+	assert.Contains(t, got, "type Timestamp = int64")
+	assert.Contains(t, got, "func TimestampPtr(v Timestamp) *Timestamp")
+	assert.False(t, typedefHasMethods(ts, &Options{UseTypeAliases: true}))
+}
+
+func TestRenderTypedefDistinctType(t *testing.T) {
+	ts := &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}}
+
+	got := renderTypedef("Timestamp", ts, &Options{})
+	assert.Contains(t, got, "type Timestamp int64")
+	assert.True(t, typedefHasMethods(ts, &Options{}))
+}
+
+func TestRenderTypedefStructTarget(t *testing.T) {
+	ts := &compile.TypedefSpec{Name: "Primitives", Target: &compile.StructSpec{Name: "PrimitiveRequiredStruct"}}
+
+	got := renderTypedef("Primitives", ts, &Options{UseTypeAliases: true})
+	assert.Contains(t, got, "type Primitives = PrimitiveRequiredStruct")
+}
+
+func TestRenderTypedefMethodsSkipsAlias(t *testing.T) {
+	ts := &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}}
+
+	assert.Empty(t, renderTypedefMethods("Timestamp", ts, &Options{UseTypeAliases: true}))
+}
+
+func TestRenderTypedefMethodsDistinctType(t *testing.T) {
+	ts := &compile.TypedefSpec{Name: "Timestamp", Target: &compile.I64Spec{}}
+
+	got := renderTypedefMethods("Timestamp", ts, &Options{})
+	assert.Contains(t, got, "func (v Timestamp) String() string {")
+	assert.Contains(t, got, "fmt.Sprintf(\"%v\", int64(v))")
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// contentHash returns the hex-encoded SHA-1 of contents, mirroring how
+// ThriftModule.SHA1 is computed for the raw IDL embedded in generated
+// code (see gen/internal/tests/nozap for an example of that field).
+// Generate uses it to decide whether a file on disk already matches what
+// it's about to write, so that re-running code generation over an
+// unchanged Thrift tree doesn't touch file mtimes or, downstream, cause
+// build systems that key on output hashes to do unnecessary work.
+func contentHash(contents []byte) string {
+	sum := sha1.Sum(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFiles writes files (keyed by path relative to o.OutputDir) to
+// disk, skipping any file whose on-disk content hash already matches.
+//
+// If o.Check is set, nothing is written; writeFiles instead returns an
+// error listing every path that would have changed, suitable for CI
+// gating of committed generated code.
+func writeFiles(files map[string][]byte, o *Options) error {
+	relPaths := make([]string, 0, len(files))
+	for relPath := range files {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	var stale []string
+	for _, relPath := range relPaths {
+		contents := files[relPath]
+		fullPath := filepath.Join(o.OutputDir, relPath)
+
+		unchanged, err := fileMatches(fullPath, contents)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			continue
+		}
+
+		if o.Check {
+			stale = append(stale, relPath)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(fullPath, contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("generated code is out of date, re-run code generation: %v", stale)
+	}
+	return nil
+}
+
+// fileMatches reports whether the file at fullPath already exists and
+// has the same content hash as contents.
+func fileMatches(fullPath string, contents []byte) (bool, error) {
+	existing, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return contentHash(existing) == contentHash(contents), nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2015 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFilesSkipsUnchangedContent(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "thriftrw-write-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(outputDir)
+
+	files := map[string][]byte{"foo/foo.go": []byte("package foo\n")}
+	require.NoError(t, writeFiles(files, &Options{OutputDir: outputDir}))
+
+	fullPath := filepath.Join(outputDir, "foo/foo.go")
+	info, err := os.Stat(fullPath)
+	require.NoError(t, err)
+	mtime := info.ModTime()
+
+	// Make sure a second, identical write would be observable as a new
+	// mtime if writeFiles didn't skip it.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, writeFiles(files, &Options{OutputDir: outputDir}))
+
+	info, err = os.Stat(fullPath)
+	require.NoError(t, err)
+	assert.Equal(t, mtime, info.ModTime(), "unchanged content should not be rewritten")
+}
+
+func TestWriteFilesCheckMode(t *testing.T) {
+	outputDir, err := ioutil.TempDir("", "thriftrw-write-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(outputDir)
+
+	files := map[string][]byte{"foo/foo.go": []byte("package foo\n")}
+
+	err = writeFiles(files, &Options{OutputDir: outputDir, Check: true})
+	require.Error(t, err, "missing file should fail --check")
+
+	_, statErr := os.Stat(filepath.Join(outputDir, "foo/foo.go"))
+	assert.True(t, os.IsNotExist(statErr), "--check must not write files")
+
+	require.NoError(t, writeFiles(files, &Options{OutputDir: outputDir}))
+	assert.NoError(t, writeFiles(files, &Options{OutputDir: outputDir, Check: true}), "up-to-date tree should pass --check")
+}
+
+func TestContentHashStable(t *testing.T) {
+	a := contentHash([]byte("hello world\n"))
+	b := contentHash([]byte("hello world\n"))
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, contentHash([]byte("hello world")))
+}
+
+func TestParallelEachRunsAllAndPropagatesError(t *testing.T) {
+	var count int64
+	require.NoError(t, parallelEach(50, 4, func(i int) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	}))
+	assert.EqualValues(t, 50, atomic.LoadInt64(&count))
+
+	err := parallelEach(10, 4, func(i int) error {
+		if i == 5 {
+			return assert.AnError
+		}
+		return nil
+	})
+	assert.Error(t, err)
+}